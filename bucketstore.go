@@ -0,0 +1,118 @@
+package topk
+
+// bucketStore holds the depth*width grid of (fingerprint, count) buckets
+// backing a HeavyKeeper. It is implemented by bucketStore16/32/64, which
+// differ only in how many bits of the fingerprint hash they keep: narrower
+// fingerprints cause more false-positive collisions between distinct flows.
+// A bucket16 is padded to 8 bytes, the same size as a bucket32, so 16-bit
+// fingerprints use no less memory than 32-bit ones; a bucket64 takes 16 bytes.
+type bucketStore interface {
+	len() int
+	get(i int) (fingerprint uint64, count uint32)
+	set(i int, fingerprint uint64, count uint32)
+	decayAll(pct float64)
+	reset()
+}
+
+func newBucketStore(n int, fpBits int) bucketStore {
+	switch fpBits {
+	case 16:
+		return make(bucketStore16, n)
+	case 32:
+		return make(bucketStore32, n)
+	case 64:
+		return make(bucketStore64, n)
+	default:
+		panic("FingerprintBits must be 16, 32, or 64")
+	}
+}
+
+type bucket16 struct {
+	fingerprint uint16
+	count       uint32
+}
+
+type bucketStore16 []bucket16
+
+func (s bucketStore16) len() int { return len(s) }
+
+func (s bucketStore16) get(i int) (uint64, uint32) {
+	b := s[i]
+	return uint64(b.fingerprint), b.count
+}
+
+func (s bucketStore16) set(i int, fingerprint uint64, count uint32) {
+	s[i] = bucket16{fingerprint: uint16(fingerprint), count: count}
+}
+
+func (s bucketStore16) decayAll(pct float64) {
+	for i := range s {
+		s[i].count = uint32(float64(s[i].count) * pct)
+	}
+}
+
+func (s bucketStore16) reset() {
+	for i := range s {
+		s[i] = bucket16{}
+	}
+}
+
+type bucket32 struct {
+	fingerprint uint32
+	count       uint32
+}
+
+type bucketStore32 []bucket32
+
+func (s bucketStore32) len() int { return len(s) }
+
+func (s bucketStore32) get(i int) (uint64, uint32) {
+	b := s[i]
+	return uint64(b.fingerprint), b.count
+}
+
+func (s bucketStore32) set(i int, fingerprint uint64, count uint32) {
+	s[i] = bucket32{fingerprint: uint32(fingerprint), count: count}
+}
+
+func (s bucketStore32) decayAll(pct float64) {
+	for i := range s {
+		s[i].count = uint32(float64(s[i].count) * pct)
+	}
+}
+
+func (s bucketStore32) reset() {
+	for i := range s {
+		s[i] = bucket32{}
+	}
+}
+
+type bucket64 struct {
+	fingerprint uint64
+	count       uint32
+}
+
+type bucketStore64 []bucket64
+
+func (s bucketStore64) len() int { return len(s) }
+
+func (s bucketStore64) get(i int) (uint64, uint32) {
+	b := s[i]
+	return b.fingerprint, b.count
+}
+
+func (s bucketStore64) set(i int, fingerprint uint64, count uint32) {
+	s[i] = bucket64{fingerprint: fingerprint, count: count}
+}
+
+func (s bucketStore64) decayAll(pct float64) {
+	for i := range s {
+		s[i].count = uint32(float64(s[i].count) * pct)
+	}
+}
+
+func (s bucketStore64) reset() {
+	for i := range s {
+		s[i] = bucket64{}
+	}
+}
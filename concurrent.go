@@ -0,0 +1,134 @@
+package topk
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/OneOfOne/xxhash"
+)
+
+// ConcurrentHeavyKeeper is a sharded wrapper around HeavyKeeper that is safe
+// for concurrent use from many goroutines. Flows are hashed to a fixed shard
+// so that a given flow is always tracked by the same underlying HeavyKeeper,
+// each of which is protected by its own mutex to avoid contention between
+// shards. Top() merges the per-shard heaps into a single top-K view.
+type ConcurrentHeavyKeeper[T comparable] struct {
+	k         int
+	serialize SerializeFunc[T]
+	bufPool   sync.Pool
+	shards    []*hkShard[T]
+}
+
+type hkShard[T comparable] struct {
+	mu    sync.Mutex
+	hk    *HeavyKeeper[T]
+	queue chan sampleOp[T]
+	wg    sync.WaitGroup
+}
+
+type sampleOp[T comparable] struct {
+	flow T
+	incr uint32
+}
+
+// NewConcurrent returns a ConcurrentHeavyKeeper that tracks the k largest
+// flows across the given number of shards. Each shard is an independent
+// HeavyKeeper built with New(k, decay, serialize), and queueSize controls
+// how many SampleAsync calls may be buffered per shard before it blocks.
+//
+// shards should be chosen relative to expected goroutine concurrency; more
+// shards reduce contention at the cost of a coarser top-K (each shard only
+// sees the flows hashed to it, so very skewed key spaces may want fewer,
+// larger shards).
+func NewConcurrent[T comparable](shards, k int, decay float64, queueSize int, serialize SerializeFunc[T]) *ConcurrentHeavyKeeper[T] {
+	if shards < 1 {
+		panic("shards must be >= 1")
+	}
+
+	chk := &ConcurrentHeavyKeeper[T]{
+		k:         k,
+		serialize: serialize,
+		bufPool:   sync.Pool{New: func() interface{} { return make([]byte, 0, 64) }},
+		shards:    make([]*hkShard[T], shards),
+	}
+
+	for i := range chk.shards {
+		s := &hkShard[T]{
+			hk:    New(k, decay, serialize),
+			queue: make(chan sampleOp[T], queueSize),
+		}
+		chk.shards[i] = s
+		go s.run()
+	}
+
+	return chk
+}
+
+func (s *hkShard[T]) run() {
+	for op := range s.queue {
+		s.mu.Lock()
+		s.hk.Sample(op.flow, op.incr)
+		s.mu.Unlock()
+		s.wg.Done()
+	}
+}
+
+func (chk *ConcurrentHeavyKeeper[T]) shardFor(flow T) *hkShard[T] {
+	buf := chk.bufPool.Get().([]byte)
+	buf = chk.serialize(buf[:0], flow)
+	h := xxhash.Checksum32S(buf, 0)
+	chk.bufPool.Put(buf)
+	return chk.shards[h%uint32(len(chk.shards))]
+}
+
+// Sample increments the given flow's count by the given amount, blocking
+// until the update has been applied. It returns true if the flow is in the
+// top K elements of its shard.
+func (chk *ConcurrentHeavyKeeper[T]) Sample(flow T, incr uint32) bool {
+	s := chk.shardFor(flow)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hk.Sample(flow, incr)
+}
+
+// SampleAsync enqueues an increment of the given flow's count by the given
+// amount, to be applied by a background worker. It does not block on the
+// update itself, only on the shard's queue if it is full. Call Wait to block
+// until all enqueued samples have been applied.
+func (chk *ConcurrentHeavyKeeper[T]) SampleAsync(flow T, incr uint32) {
+	s := chk.shardFor(flow)
+	s.wg.Add(1)
+	s.queue <- sampleOp[T]{flow: flow, incr: incr}
+}
+
+// Wait blocks until all samples enqueued via SampleAsync have been applied.
+func (chk *ConcurrentHeavyKeeper[T]) Wait() {
+	for _, s := range chk.shards {
+		s.wg.Wait()
+	}
+}
+
+// Close drains any pending SampleAsync calls and stops the background
+// workers. The ConcurrentHeavyKeeper must not be used after Close returns.
+func (chk *ConcurrentHeavyKeeper[T]) Close() {
+	chk.Wait()
+	for _, s := range chk.shards {
+		close(s.queue)
+	}
+}
+
+// Top returns the top K flows across all shards.
+func (chk *ConcurrentHeavyKeeper[T]) Top() []FlowCount[T] {
+	var merged []FlowCount[T]
+	for _, s := range chk.shards {
+		s.mu.Lock()
+		merged = append(merged, s.hk.Top()...)
+		s.mu.Unlock()
+	}
+
+	sort.Stable(sort.Reverse(byCount[T](merged)))
+	if len(merged) > chk.k {
+		merged = merged[:chk.k]
+	}
+	return merged
+}
@@ -0,0 +1,59 @@
+package topk
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentHeavyKeeper_Sample(t *testing.T) {
+	chk := NewConcurrent(4, 5, 0.9, 16, StringKey)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chk.Sample("hot", 1)
+		}()
+	}
+	wg.Wait()
+
+	count, ok := func() (uint32, bool) {
+		for _, fc := range chk.Top() {
+			if fc.Flow == "hot" {
+				return fc.Count, true
+			}
+		}
+		return 0, false
+	}()
+	if !ok {
+		t.Fatalf("expected \"hot\" to be in the top K")
+	}
+	if count != 100 {
+		t.Fatalf("expected count of 100, got %d", count)
+	}
+}
+
+func TestConcurrentHeavyKeeper_SampleAsync(t *testing.T) {
+	chk := NewConcurrent(4, 5, 0.9, 16, StringKey)
+
+	for i := 0; i < 50; i++ {
+		chk.SampleAsync("a", 1)
+		chk.SampleAsync("b", 2)
+	}
+	chk.Wait()
+
+	counts := map[string]uint32{}
+	for _, fc := range chk.Top() {
+		counts[fc.Flow] = fc.Count
+	}
+
+	if counts["a"] != 50 {
+		t.Fatalf("expected a=50, got %d", counts["a"])
+	}
+	if counts["b"] != 100 {
+		t.Fatalf("expected b=100, got %d", counts["b"])
+	}
+
+	chk.Close()
+}
@@ -0,0 +1,68 @@
+package topk
+
+import "math"
+
+// Estimate returns the approximate count for flow, even if it isn't (or is
+// no longer) one of the top K flows. It works by hashing flow to its bucket
+// in each row, as Sample does, and taking the minimum count across the rows
+// whose bucket's stored fingerprint actually matches flow's; rows where a
+// different flow currently owns the bucket are skipped, since they carry no
+// signal about flow's count. It returns 0 if no row's bucket currently
+// matches flow, i.e. HeavyKeeper has no record of ever seeing it (or its
+// count decayed away).
+//
+// Count, by contrast, only answers for flows that made the top K.
+func (hk *HeavyKeeper[T]) Estimate(flow T) uint32 {
+	hk.buf = hk.serialize(hk.buf[:0], flow)
+	data := hk.buf
+	fp := hk.fingerprint(data)
+
+	var (
+		min   uint32
+		found bool
+	)
+	for i := 0; i < hk.depth; i++ {
+		j := int(hk.slot(data, uint32(i))) + i*hk.width
+
+		bucketFP, count := hk.buckets.get(j)
+		if bucketFP != fp || count == 0 {
+			continue
+		}
+		if !found || count < min {
+			min = count
+			found = true
+		}
+	}
+
+	if !found {
+		return 0
+	}
+	return min
+}
+
+// Cardinality estimates the number of distinct flows ever sampled, using a
+// linear-counting estimate over the first row's buckets: the fraction of
+// buckets that are still empty implies how much of the hash space has been
+// touched. Like Estimate, this is a lossy approximation derived from the
+// underlying sketch, not an exact count.
+func (hk *HeavyKeeper[T]) Cardinality() uint64 {
+	width := hk.width
+
+	empty := 0
+	for i := 0; i < width; i++ {
+		if _, count := hk.buckets.get(i); count == 0 {
+			empty++
+		}
+	}
+	if empty == 0 {
+		// All buckets in the row are occupied; fall back to the largest
+		// value linear counting can express for this width to avoid log(0).
+		empty = 1
+	}
+
+	estimate := -float64(width) * math.Log(float64(empty)/float64(width))
+	if estimate < 0 {
+		return 0
+	}
+	return uint64(estimate)
+}
@@ -0,0 +1,39 @@
+package topk
+
+import "testing"
+
+func TestEstimate(t *testing.T) {
+	hk := New(2, 0.9, StringKey)
+	hk.Sample("a", 100)
+	hk.Sample("b", 50)
+	hk.Sample("c", 60)
+
+	// "c" has a larger count than "b", so "b" (the current minimum) is the
+	// one evicted from the top K.
+	if _, ok := hk.Count("b"); ok {
+		t.Fatalf("expected \"b\" to no longer be in the top K")
+	}
+
+	// Estimate should still find "b" via the underlying sketch even though
+	// it fell out of the top K.
+	got := hk.Estimate("b")
+	if got == 0 || got > 50 {
+		t.Fatalf("expected Estimate(b) to be in (0, 50], got %d", got)
+	}
+
+	if got := hk.Estimate("never-sampled"); got != 0 {
+		t.Fatalf("expected Estimate of an unseen flow to be 0, got %d", got)
+	}
+}
+
+func TestCardinality(t *testing.T) {
+	hk := New(100, 0.9, StringKey)
+	for i := 0; i < 500; i++ {
+		hk.Sample(randString(16), 1)
+	}
+
+	got := hk.Cardinality()
+	if got < 250 || got > 1000 {
+		t.Fatalf("expected a rough cardinality estimate near 500, got %d", got)
+	}
+}
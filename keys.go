@@ -0,0 +1,15 @@
+package topk
+
+// StringKey is a SerializeFunc for string keys, suitable for
+// New[string](k, decay, topk.StringKey) or the equivalent inferred call
+// New(k, decay, topk.StringKey).
+func StringKey(dst []byte, v string) []byte {
+	return append(dst, v...)
+}
+
+// ParseStringKey is the DeserializeFunc counterpart to StringKey, for use
+// with NewWithCodec when a HeavyKeeper[string] needs to support
+// MarshalBinary/UnmarshalBinary or WriteTo/ReadFrom.
+func ParseStringKey(data []byte) (string, error) {
+	return string(data), nil
+}
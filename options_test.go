@@ -0,0 +1,69 @@
+package topk
+
+import (
+	"testing"
+
+	"github.com/OneOfOne/xxhash"
+)
+
+func TestNewWithOptions_fingerprintBits(t *testing.T) {
+	for _, bits := range []int{16, 32, 64} {
+		hk := NewWithOptions(Options[string]{K: 5, Decay: 0.9, Serialize: StringKey, FingerprintBits: bits})
+
+		hk.Sample("a", 10)
+		hk.Sample("b", 5)
+
+		top := hk.Top()
+		if len(top) != 2 {
+			t.Fatalf("FingerprintBits=%d: expected 2 flows, got %v", bits, top)
+		}
+		if top[0].Flow != "a" || top[0].Count != 10 {
+			t.Fatalf("FingerprintBits=%d: expected a=10 first, got %v", bits, top)
+		}
+	}
+}
+
+func TestNewWithOptions_badFingerprintBits(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for an unsupported FingerprintBits")
+		}
+	}()
+	NewWithOptions(Options[string]{K: 5, Decay: 0.9, Serialize: StringKey, FingerprintBits: 24})
+}
+
+func TestNewWithOptions_fingerprintBitsRoundTrip(t *testing.T) {
+	for _, bits := range []int{16, 32, 64} {
+		hk := NewWithOptions(Options[string]{K: 5, Decay: 0.9, Serialize: StringKey, Deserialize: ParseStringKey, FingerprintBits: bits})
+		hk.Sample("a", 10)
+		hk.Sample("b", 5)
+		hk.Sample("c", 20)
+
+		data, err := hk.MarshalBinary()
+		if err != nil {
+			t.Fatalf("FingerprintBits=%d: MarshalBinary: %v", bits, err)
+		}
+
+		restored := NewWithOptions(Options[string]{K: 5, Decay: 0.9, Serialize: StringKey, Deserialize: ParseStringKey, FingerprintBits: bits})
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatalf("FingerprintBits=%d: UnmarshalBinary: %v", bits, err)
+		}
+
+		assert(t, hk.Top(), restored.Top())
+	}
+}
+
+func TestNewWithOptions_customHasher(t *testing.T) {
+	calls := 0
+	hasher := func(data []byte, seed uint32) uint32 {
+		calls++
+		return xxhash.Checksum32S(data, seed)
+	}
+
+	hk := NewWithOptions(Options[string]{K: 5, Decay: 0.9, Serialize: StringKey, Hasher: hasher})
+	hk.Sample("a", 1)
+
+	if calls == 0 {
+		t.Fatalf("expected the custom Hasher to be called")
+	}
+}
@@ -0,0 +1,250 @@
+package topk
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// hkVersion1 is the original wire format, which always used 32-bit
+// fingerprints. hkVersion2 adds an explicit fingerprint-width byte so that
+// snapshots from a HeavyKeeper built with a non-default FingerprintBits can
+// round-trip; ReadFrom still accepts version 1 for backwards compatibility.
+const (
+	hkVersion1 byte = 1
+	hkVersion2 byte = 2
+)
+
+// MarshalBinary encodes the full state of the HeavyKeeper (buckets, heap,
+// depth, width, fingerprint width, and decay) so that it can be persisted or
+// shipped to another process. Encoding only uses hk's SerializeFunc; the
+// HeavyKeeper that decodes the snapshot with UnmarshalBinary must have been
+// built with NewWithCodec or NewWithOptions (with Deserialize set), so that
+// the heap's keys can be reconstructed.
+func (hk *HeavyKeeper[T]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := hk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the HeavyKeeper's state with the snapshot encoded
+// by MarshalBinary. It returns an error if the data is truncated, has an
+// unrecognized version byte, or has an inconsistent bucket count.
+func (hk *HeavyKeeper[T]) UnmarshalBinary(data []byte) error {
+	_, err := hk.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// WriteTo writes the HeavyKeeper's state to w in the same format as
+// MarshalBinary, and returns the number of bytes written.
+func (hk *HeavyKeeper[T]) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte(hkVersion2)
+	buf.WriteByte(byte(hk.fpBits))
+	binary.Write(&buf, binary.BigEndian, math.Float64bits(hk.decay))
+	binary.Write(&buf, binary.BigEndian, uint32(hk.depth))
+	binary.Write(&buf, binary.BigEndian, uint32(hk.width))
+
+	binary.Write(&buf, binary.BigEndian, uint32(hk.buckets.len()))
+	for i := 0; i < hk.buckets.len(); i++ {
+		fp, count := hk.buckets.get(i)
+		binary.Write(&buf, binary.BigEndian, fp)
+		binary.Write(&buf, binary.BigEndian, count)
+	}
+
+	binary.Write(&buf, binary.BigEndian, uint32(len(hk.heap)))
+	var keyBuf []byte
+	for _, fc := range hk.heap {
+		keyBuf = hk.serialize(keyBuf[:0], fc.Flow)
+		binary.Write(&buf, binary.BigEndian, uint32(len(keyBuf)))
+		buf.Write(keyBuf)
+		binary.Write(&buf, binary.BigEndian, fc.Count)
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// ReadFrom replaces the HeavyKeeper's state with a snapshot read from r, and
+// returns the number of bytes consumed. See UnmarshalBinary for the
+// validation performed. hk must have a DeserializeFunc configured (see
+// NewWithCodec) to reconstruct the heap's keys.
+func (hk *HeavyKeeper[T]) ReadFrom(r io.Reader) (int64, error) {
+	if hk.deserialize == nil {
+		return 0, fmt.Errorf("topk: HeavyKeeper has no DeserializeFunc configured; build it with NewWithCodec")
+	}
+
+	data, err := io.ReadAll(r)
+	n := int64(len(data))
+	if err != nil {
+		return n, err
+	}
+
+	if len(data) < 1 {
+		return n, fmt.Errorf("topk: snapshot is empty")
+	}
+	version := data[0]
+	rest := data[1:]
+
+	var fpBits int
+	switch version {
+	case hkVersion1:
+		fpBits = 32
+	case hkVersion2:
+		if len(rest) < 1 {
+			return n, fmt.Errorf("topk: snapshot truncated before fingerprint width")
+		}
+		fpBits = int(rest[0])
+		rest = rest[1:]
+	default:
+		return n, fmt.Errorf("topk: unsupported snapshot version %d", version)
+	}
+	if fpBits != 16 && fpBits != 32 && fpBits != 64 {
+		return n, fmt.Errorf("topk: unsupported fingerprint width %d", fpBits)
+	}
+	buf := bytes.NewReader(rest)
+
+	var decayBits uint64
+	if err := binary.Read(buf, binary.BigEndian, &decayBits); err != nil {
+		return n, fmt.Errorf("topk: reading decay: %w", err)
+	}
+
+	var depth, width, numBuckets uint32
+	if err := binary.Read(buf, binary.BigEndian, &depth); err != nil {
+		return n, fmt.Errorf("topk: reading depth: %w", err)
+	}
+	if err := binary.Read(buf, binary.BigEndian, &width); err != nil {
+		return n, fmt.Errorf("topk: reading width: %w", err)
+	}
+	if err := binary.Read(buf, binary.BigEndian, &numBuckets); err != nil {
+		return n, fmt.Errorf("topk: reading bucket count: %w", err)
+	}
+	if uint64(depth)*uint64(width) != uint64(numBuckets) {
+		return n, fmt.Errorf("topk: depth*width (%d*%d) does not match bucket count %d", depth, width, numBuckets)
+	}
+
+	buckets := newBucketStore(int(numBuckets), fpBits)
+	for i := 0; i < buckets.len(); i++ {
+		var fp uint64
+		var count uint32
+		if version == hkVersion1 {
+			var fp32 uint32
+			if err := binary.Read(buf, binary.BigEndian, &fp32); err != nil {
+				return n, fmt.Errorf("topk: reading bucket %d fingerprint: %w", i, err)
+			}
+			fp = uint64(fp32)
+		} else if err := binary.Read(buf, binary.BigEndian, &fp); err != nil {
+			return n, fmt.Errorf("topk: reading bucket %d fingerprint: %w", i, err)
+		}
+		if err := binary.Read(buf, binary.BigEndian, &count); err != nil {
+			return n, fmt.Errorf("topk: reading bucket %d count: %w", i, err)
+		}
+		buckets.set(i, fp, count)
+	}
+
+	var numHeap uint32
+	if err := binary.Read(buf, binary.BigEndian, &numHeap); err != nil {
+		return n, fmt.Errorf("topk: reading heap size: %w", err)
+	}
+
+	h := make(minHeap[T], numHeap)
+	for i := range h {
+		var keyLen uint32
+		if err := binary.Read(buf, binary.BigEndian, &keyLen); err != nil {
+			return n, fmt.Errorf("topk: reading heap entry %d key length: %w", i, err)
+		}
+		keyBytes := make([]byte, keyLen)
+		if _, err := io.ReadFull(buf, keyBytes); err != nil {
+			return n, fmt.Errorf("topk: reading heap entry %d key: %w", i, err)
+		}
+		key, err := hk.deserialize(keyBytes)
+		if err != nil {
+			return n, fmt.Errorf("topk: deserializing heap entry %d key: %w", i, err)
+		}
+		var count uint32
+		if err := binary.Read(buf, binary.BigEndian, &count); err != nil {
+			return n, fmt.Errorf("topk: reading heap entry %d count: %w", i, err)
+		}
+		h[i] = FlowCount[T]{Flow: key, Count: count}
+	}
+
+	hk.decay = math.Float64frombits(decayBits)
+	hk.depth = int(depth)
+	hk.width = int(width)
+	hk.fpBits = fpBits
+	hk.buckets = buckets
+	hk.heap = h
+
+	return n, nil
+}
+
+// Merge elementwise combines other's bucket counts into hk, keeping the
+// fingerprint with the larger count when the two sketches disagree on which
+// flow owns a bucket, and then rebuilds hk's top-K heap from the union of
+// both heaps. hk and other must have been built with the same depth, width,
+// and fingerprint width (e.g. the same k and Options). This is useful for
+// combining top-K state sampled independently across shards or hosts into a
+// single global view.
+func (hk *HeavyKeeper[T]) Merge(other *HeavyKeeper[T]) error {
+	if hk.depth != other.depth || hk.width != other.width {
+		return fmt.Errorf("topk: cannot merge HeavyKeeper with mismatched dimensions (depth %d/%d, width %d/%d)",
+			hk.depth, other.depth, hk.width, other.width)
+	}
+	if hk.fpBits != other.fpBits {
+		return fmt.Errorf("topk: cannot merge HeavyKeeper with mismatched fingerprint widths (%d/%d)", hk.fpBits, other.fpBits)
+	}
+
+	for i := 0; i < hk.buckets.len(); i++ {
+		aFP, aCount := hk.buckets.get(i)
+		bFP, bCount := other.buckets.get(i)
+		switch {
+		case aCount == 0:
+			hk.buckets.set(i, bFP, bCount)
+		case bCount == 0:
+			// keep a
+		case aFP == bFP:
+			hk.buckets.set(i, aFP, aCount+bCount)
+		case bCount > aCount:
+			hk.buckets.set(i, bFP, bCount)
+		}
+	}
+
+	// The pre-merge heap counts are stale now that the buckets underneath
+	// them have changed: re-derive each candidate flow's count from the
+	// merged bucket store, the same way Estimate does, rather than maxing
+	// the old per-sketch heap values.
+	flows := make(map[T]struct{}, len(hk.heap)+len(other.heap))
+	for _, fc := range hk.heap {
+		if fc.Count > 0 {
+			flows[fc.Flow] = struct{}{}
+		}
+	}
+	for _, fc := range other.heap {
+		if fc.Count > 0 {
+			flows[fc.Flow] = struct{}{}
+		}
+	}
+
+	top := make([]FlowCount[T], 0, len(flows))
+	for flow := range flows {
+		top = append(top, FlowCount[T]{Flow: flow, Count: hk.Estimate(flow)})
+	}
+	sort.Stable(sort.Reverse(byCount[T](top)))
+
+	k := len(hk.heap)
+	newHeap := make(minHeap[T], k)
+	for i := 0; i < k && i < len(top); i++ {
+		newHeap[i] = top[i]
+	}
+	heap.Init(&newHeap)
+	hk.heap = newHeap
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package topk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// v2ToV1 rewrites a version-2 snapshot (produced by WriteTo/MarshalBinary)
+// into the version-1 format it was derived from: no fingerprint-width byte,
+// and 32-bit fingerprints instead of 64-bit ones. It's used to exercise the
+// legacy decode branches in ReadFrom with a payload whose fingerprints and
+// counts are guaranteed to be internally consistent, rather than hand-typed
+// magic numbers.
+func v2ToV1(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if data[0] != hkVersion2 {
+		t.Fatalf("expected a version-2 snapshot, got version %d", data[0])
+	}
+	fpBits := data[1]
+	if fpBits != 32 {
+		t.Fatalf("expected a 32-bit fingerprint snapshot, got %d bits", fpBits)
+	}
+
+	buf := bytes.NewReader(data[2:])
+
+	var decayBits uint64
+	var depth, width, numBuckets uint32
+	if err := binary.Read(buf, binary.BigEndian, &decayBits); err != nil {
+		t.Fatalf("reading decay: %v", err)
+	}
+	if err := binary.Read(buf, binary.BigEndian, &depth); err != nil {
+		t.Fatalf("reading depth: %v", err)
+	}
+	if err := binary.Read(buf, binary.BigEndian, &width); err != nil {
+		t.Fatalf("reading width: %v", err)
+	}
+	if err := binary.Read(buf, binary.BigEndian, &numBuckets); err != nil {
+		t.Fatalf("reading bucket count: %v", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteByte(hkVersion1)
+	binary.Write(&out, binary.BigEndian, decayBits)
+	binary.Write(&out, binary.BigEndian, depth)
+	binary.Write(&out, binary.BigEndian, width)
+	binary.Write(&out, binary.BigEndian, numBuckets)
+
+	for i := uint32(0); i < numBuckets; i++ {
+		var fp uint64
+		var count uint32
+		if err := binary.Read(buf, binary.BigEndian, &fp); err != nil {
+			t.Fatalf("reading bucket %d fingerprint: %v", i, err)
+		}
+		if err := binary.Read(buf, binary.BigEndian, &count); err != nil {
+			t.Fatalf("reading bucket %d count: %v", i, err)
+		}
+		binary.Write(&out, binary.BigEndian, uint32(fp))
+		binary.Write(&out, binary.BigEndian, count)
+	}
+
+	// The heap section's layout doesn't depend on the snapshot version, so
+	// it carries over byte-for-byte.
+	rest, err := io.ReadAll(buf)
+	if err != nil {
+		t.Fatalf("reading heap section: %v", err)
+	}
+	out.Write(rest)
+
+	return out.Bytes()
+}
+
+func TestUnmarshalBinary_version1(t *testing.T) {
+	hk := NewWithCodec(5, 0.9, StringKey, ParseStringKey)
+	hk.Sample("a", 3)
+	hk.Sample("b", 6)
+	hk.Sample("c", 13)
+
+	v2, err := hk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	v1 := v2ToV1(t, v2)
+	if v1[0] != hkVersion1 {
+		t.Fatalf("expected a version-1 payload, got version %d", v1[0])
+	}
+
+	restored := NewWithCodec(5, 0.9, StringKey, ParseStringKey)
+	if err := restored.UnmarshalBinary(v1); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+
+	assert(t, hk.Top(), restored.Top())
+}
+
+func TestMarshalUnmarshalBinary(t *testing.T) {
+	hk := NewWithCodec(5, 0.9, StringKey, ParseStringKey)
+	hk.Sample("a", 3)
+	hk.Sample("b", 6)
+	hk.Sample("c", 13)
+
+	data, err := hk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	restored := NewWithCodec(5, 0.9, StringKey, ParseStringKey)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+
+	assert(t, hk.Top(), restored.Top())
+}
+
+func TestUnmarshalBinary_noCodec(t *testing.T) {
+	hk := New(5, 0.9, StringKey)
+	if err := hk.UnmarshalBinary([]byte{hkVersion1}); err == nil {
+		t.Fatalf("expected an error when no DeserializeFunc is configured")
+	}
+}
+
+func TestUnmarshalBinary_badVersion(t *testing.T) {
+	hk := NewWithCodec(5, 0.9, StringKey, ParseStringKey)
+	if err := hk.UnmarshalBinary([]byte{0xff}); err == nil {
+		t.Fatalf("expected an error for an unrecognized version byte")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := New(5, 0.9, StringKey)
+	a.Sample("x", 10)
+	a.Sample("y", 5)
+
+	b := New(5, 0.9, StringKey)
+	b.Sample("x", 7)
+	b.Sample("z", 20)
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	top := a.Top()
+	counts := map[string]uint32{}
+	for _, fc := range top {
+		counts[fc.Flow] = fc.Count
+	}
+
+	if counts["z"] != 20 {
+		t.Fatalf("expected z=20, got %d", counts["z"])
+	}
+	if counts["y"] != 5 {
+		t.Fatalf("expected y=5, got %d", counts["y"])
+	}
+	if counts["x"] != 17 {
+		t.Fatalf("expected x=17 (10 from a + 7 from b), got %d", counts["x"])
+	}
+}
+
+func TestMerge_mismatchedDimensions(t *testing.T) {
+	a := New(5, 0.9, StringKey)
+	b := New(10_000, 0.9, StringKey)
+
+	if err := a.Merge(b); err == nil {
+		t.Fatalf("expected an error when merging sketches with different dimensions")
+	}
+}
@@ -13,26 +13,85 @@ import (
 // Accurate Algorithm for Finding Top-k Elephant Flows" at
 // https://www.usenix.org/system/files/conference/atc18/atc18-gong.pdf
 //
-// HeavyKeeper is not safe for concurrent use.
-type HeavyKeeper struct {
+// HeavyKeeper is generic over the key type T, which may be any comparable
+// type (string, a fixed-size array, a small struct, etc) as long as a
+// SerializeFunc is provided to turn a T into bytes for hashing.
+//
+// HeavyKeeper is not safe for concurrent use; see ConcurrentHeavyKeeper.
+type HeavyKeeper[T comparable] struct {
 	decay   float64
 	depth   int
 	width   int
-	buckets []bucket
-	heap    minHeap
+	fpBits  int
+	hasher  Hasher
+	buckets bucketStore
+	heap    minHeap[T]
+
+	serialize   SerializeFunc[T]
+	deserialize DeserializeFunc[T]
+	buf         []byte
 }
 
-type bucket struct {
-	fingerprint uint32
-	count       uint32
+// SerializeFunc appends the bytes of v to dst and returns the extended
+// slice, in the style of append, so that callers can reuse a scratch buffer
+// across calls instead of allocating on every Sample.
+type SerializeFunc[T any] func(dst []byte, v T) []byte
+
+// DeserializeFunc parses the bytes produced by a SerializeFunc back into a
+// T. It is only required to use MarshalBinary/UnmarshalBinary or
+// WriteTo/ReadFrom; HeavyKeepers built with New do not need one.
+type DeserializeFunc[T any] func(data []byte) (T, error)
+
+// Hasher hashes data into a 32-bit digest using the given seed. Passing
+// different seeds for the same data is expected to produce independent
+// digests; this is how HeavyKeeper derives both bucket slots and
+// fingerprints from a single Hasher.
+type Hasher func(data []byte, seed uint32) uint32
+
+// Options configures a HeavyKeeper built with NewWithOptions. The zero value
+// of Hasher and FingerprintBits select the defaults used by New: xxhash and
+// 32-bit fingerprints.
+type Options[T comparable] struct {
+	K           int
+	Decay       float64
+	Serialize   SerializeFunc[T]
+	Deserialize DeserializeFunc[T] // optional; required for MarshalBinary/UnmarshalBinary
+
+	// Hasher is used both to pick a flow's bucket in each row and to compute
+	// its fingerprint. It defaults to xxhash.Checksum32S.
+	Hasher Hasher
+
+	// FingerprintBits is the width of the fingerprint stored per bucket: 16,
+	// 32, or 64 (0 defaults to 32). Narrower fingerprints cause more
+	// false-positive collisions; 64 bits virtually eliminates aliasing for
+	// very large K. Buckets take 8 bytes at 16 or 32 bits and 16 bytes at 64
+	// bits, so 16 bits saves no memory over 32.
+	FingerprintBits int
 }
 
-// New returns a HeavyKeeper that tracks the k largest flows. Decay determines
-// the chance that a collision will cause the existing flow count to decay. A
-// decay of 0.9 is a good starting point.
+// New returns a HeavyKeeper that tracks the k largest flows, keyed by T and
+// hashed via serialize. Decay determines the chance that a collision will
+// cause the existing flow count to decay. A decay of 0.9 is a good starting
+// point.
 //
-// Width is `k * log(k)` (minimum of 256) and depth is `log(k)` (minimum of 3).
-func New(k int, decay float64) *HeavyKeeper {
+// Width is `k * log(k)` (minimum of 256) and depth is `log(k)` (minimum of
+// 3).
+func New[T comparable](k int, decay float64, serialize SerializeFunc[T]) *HeavyKeeper[T] {
+	return NewWithOptions(Options[T]{K: k, Decay: decay, Serialize: serialize})
+}
+
+// NewWithCodec is like New, but additionally accepts a DeserializeFunc so
+// that the resulting HeavyKeeper supports MarshalBinary/UnmarshalBinary and
+// WriteTo/ReadFrom.
+func NewWithCodec[T comparable](k int, decay float64, serialize SerializeFunc[T], deserialize DeserializeFunc[T]) *HeavyKeeper[T] {
+	return NewWithOptions(Options[T]{K: k, Decay: decay, Serialize: serialize, Deserialize: deserialize})
+}
+
+// NewWithOptions returns a HeavyKeeper configured by opts. See Options for
+// the available knobs and their defaults.
+func NewWithOptions[T comparable](opts Options[T]) *HeavyKeeper[T] {
+	k, decay := opts.K, opts.Decay
+
 	if k < 1 {
 		panic("k must be >= 1")
 	}
@@ -41,6 +100,20 @@ func New(k int, decay float64) *HeavyKeeper {
 		panic("decay must be in range (0, 1.0]")
 	}
 
+	if opts.Serialize == nil {
+		panic("Serialize must not be nil")
+	}
+
+	hasher := opts.Hasher
+	if hasher == nil {
+		hasher = xxhash.Checksum32S
+	}
+
+	fpBits := opts.FingerprintBits
+	if fpBits == 0 {
+		fpBits = 32
+	}
+
 	width := int(float64(k) * math.Log(float64(k)))
 	if width < 256 {
 		width = 256
@@ -51,47 +124,79 @@ func New(k int, decay float64) *HeavyKeeper {
 		depth = 3
 	}
 
-	buckets := make([]bucket, depth*width)
+	return &HeavyKeeper[T]{
+		decay:       decay,
+		depth:       depth,
+		width:       width,
+		fpBits:      fpBits,
+		hasher:      hasher,
+		buckets:     newBucketStore(depth*width, fpBits),
+		heap:        make(minHeap[T], k),
+		serialize:   opts.Serialize,
+		deserialize: opts.Deserialize,
+	}
+}
 
-	return &HeavyKeeper{
-		decay:   decay,
-		depth:   depth,
-		width:   width,
-		buckets: buckets,
-		heap:    make(minHeap, k),
+// fingerprint hashes data into hk.fpBits bits. For widths above 32 bits it
+// combines two independently-seeded 32-bit digests from hk.hasher.
+func (hk *HeavyKeeper[T]) fingerprint(data []byte) uint64 {
+	lo := hk.hasher(data, math.MaxUint32)
+	if hk.fpBits <= 32 {
+		return uint64(lo) & fpMask(hk.fpBits)
 	}
+	hi := hk.hasher(data, math.MaxUint32-1)
+	return uint64(hi)<<32 | uint64(lo)
+}
+
+func fpMask(bits int) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<uint(bits) - 1
+}
+
+func (hk *HeavyKeeper[T]) slot(data []byte, row uint32) uint32 {
+	return hk.hasher(data, row) % uint32(hk.width)
 }
 
 // Sample increments the given flow's count by the given amount. It returns
 // true if the flow is in the top K elements.
-func (hk *HeavyKeeper) Sample(flow string, incr uint32) bool {
-	fp := fingerprint(flow)
+func (hk *HeavyKeeper[T]) Sample(flow T, incr uint32) bool {
+	hk.buf = hk.serialize(hk.buf[:0], flow)
+	data := hk.buf
+
+	fp := hk.fingerprint(data)
 	var maxCount uint32
 	heapMin := hk.heap.Min()
 
 	buckets, width, depth := hk.buckets, hk.width, hk.depth
 	for i := 0; i < depth; i++ {
-		j := int(slot(flow, uint32(i), uint32(width))) + i*width
+		j := int(hk.slot(data, uint32(i))) + i*width
 
-		if buckets[j].count == 0 {
-			buckets[j].fingerprint = fp
-			buckets[j].count = incr
+		bucketFP, count := buckets.get(j)
+		if count == 0 {
+			buckets.set(j, fp, incr)
 			maxCount = max(maxCount, incr)
-		} else if buckets[j].fingerprint == fp {
-			buckets[j].count += incr
-			maxCount = max(maxCount, buckets[j].count)
+		} else if bucketFP == fp {
+			count += incr
+			buckets.set(j, fp, count)
+			maxCount = max(maxCount, count)
 		} else {
+			replaced := false
 			for localIncr := incr; localIncr > 0; localIncr-- {
-				if rand.Float64() < math.Pow(hk.decay, float64(buckets[j].count)) {
-					buckets[j].count--
-					if buckets[j].count <= 0 {
-						buckets[j].fingerprint = fp
-						buckets[j].count = localIncr
+				if rand.Float64() < math.Pow(hk.decay, float64(count)) {
+					count--
+					if count <= 0 {
+						buckets.set(j, fp, localIncr)
 						maxCount = max(maxCount, localIncr)
+						replaced = true
 						break
 					}
 				}
 			}
+			if !replaced {
+				buckets.set(j, bucketFP, count)
+			}
 		}
 	}
 
@@ -112,14 +217,6 @@ func (hk *HeavyKeeper) Sample(flow string, incr uint32) bool {
 	return false
 }
 
-func fingerprint(flow string) uint32 {
-	return xxhash.ChecksumString32S(flow, math.MaxUint32)
-}
-
-func slot(flow string, row, width uint32) uint32 {
-	return xxhash.ChecksumString32S(flow, row) % width
-}
-
 func max(a, b uint32) uint32 {
 	if a < b {
 		return b
@@ -128,24 +225,24 @@ func max(a, b uint32) uint32 {
 }
 
 // FlowCount is a tuple of flow and estimated count.
-type FlowCount struct {
-	Flow  string
+type FlowCount[T comparable] struct {
+	Flow  T
 	Count uint32
 }
 
-type byCount []FlowCount
+type byCount[T comparable] []FlowCount[T]
 
-func (a byCount) Len() int           { return len(a) }
-func (a byCount) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byCount) Less(i, j int) bool { return a[i].Count < a[j].Count }
+func (a byCount[T]) Len() int           { return len(a) }
+func (a byCount[T]) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byCount[T]) Less(i, j int) bool { return a[i].Count < a[j].Count }
 
-func (hk *HeavyKeeper) Top() []FlowCount {
+func (hk *HeavyKeeper[T]) Top() []FlowCount[T] {
 	return hk.TopInto(nil)
 }
 
-func (hk *HeavyKeeper) TopInto(top []FlowCount) []FlowCount {
+func (hk *HeavyKeeper[T]) TopInto(top []FlowCount[T]) []FlowCount[T] {
 	top = append(top[:0], hk.heap...)
-	sort.Stable(sort.Reverse(byCount(top)))
+	sort.Stable(sort.Reverse(byCount[T](top)))
 
 	// Trim off empty values
 	end := len(top)
@@ -160,7 +257,7 @@ func (hk *HeavyKeeper) TopInto(top []FlowCount) []FlowCount {
 
 // Count returns the estimated count of the given flow if it is in the top K
 // flows.
-func (hk *HeavyKeeper) Count(flow string) (count uint32, ok bool) {
+func (hk *HeavyKeeper[T]) Count(flow T) (count uint32, ok bool) {
 	for _, hb := range hk.heap {
 		if hb.Flow == flow {
 			return hb.Count, true
@@ -170,7 +267,7 @@ func (hk *HeavyKeeper) Count(flow string) (count uint32, ok bool) {
 }
 
 // DecayAll decays all flows by the given percentage.
-func (hk *HeavyKeeper) DecayAll(pct float64) {
+func (hk *HeavyKeeper[T]) DecayAll(pct float64) {
 	if pct <= 0 {
 		return
 	} else if pct > 1 {
@@ -180,9 +277,7 @@ func (hk *HeavyKeeper) DecayAll(pct float64) {
 
 	pct = 1 - pct
 
-	for i := range hk.buckets {
-		hk.buckets[i].count = uint32(float64(hk.buckets[i].count) * pct)
-	}
+	hk.buckets.decayAll(pct)
 	for i := range hk.heap {
 		hk.heap[i].Count = uint32(float64(hk.heap[i].Count) * pct)
 	}
@@ -190,25 +285,24 @@ func (hk *HeavyKeeper) DecayAll(pct float64) {
 
 // Reset returns the HeavyKeeper to a like-new state with no flows and no
 // counts.
-func (hk *HeavyKeeper) Reset() {
-	for i := range hk.buckets {
-		hk.buckets[i] = bucket{}
-	}
+func (hk *HeavyKeeper[T]) Reset() {
+	hk.buckets.reset()
+	var zero FlowCount[T]
 	for i := range hk.heap {
-		hk.heap[i] = FlowCount{}
+		hk.heap[i] = zero
 	}
 }
 
-type minHeap []FlowCount
+type minHeap[T comparable] []FlowCount[T]
 
-var _ heap.Interface = &minHeap{}
+var _ heap.Interface = &minHeap[string]{}
 
-func (h minHeap) Len() int            { return len(h) }
-func (h minHeap) Less(i, j int) bool  { return h[i].Count < h[j].Count }
-func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
-func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(FlowCount)) }
+func (h minHeap[T]) Len() int            { return len(h) }
+func (h minHeap[T]) Less(i, j int) bool  { return h[i].Count < h[j].Count }
+func (h minHeap[T]) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap[T]) Push(x interface{}) { *h = append(*h, x.(FlowCount[T])) }
 
-func (h *minHeap) Pop() interface{} {
+func (h *minHeap[T]) Pop() interface{} {
 	old := *h
 	n := len(old)
 	x := old[n-1]
@@ -217,14 +311,14 @@ func (h *minHeap) Pop() interface{} {
 }
 
 // Min returns the minimum count in the heap or 0 if the heap is empty.
-func (h minHeap) Min() uint32 {
+func (h minHeap[T]) Min() uint32 {
 	return h[0].Count
 }
 
 // Find returns the index of the given flow in the heap so that it can be
 // updated in-place (be sure to call heap.Fix() afterwards). It returns -1 if
 // the flow doesn't exist in the heap.
-func (h minHeap) Find(flow string) (i int) {
+func (h minHeap[T]) Find(flow T) (i int) {
 	for i := range h {
 		if h[i].Flow == flow {
 			return i
@@ -7,7 +7,7 @@ import (
 	"testing"
 )
 
-func format(flowCounts []FlowCount) string {
+func format(flowCounts []FlowCount[string]) string {
 	var buf bytes.Buffer
 	buf.WriteRune('[')
 	for i, fc := range flowCounts {
@@ -20,7 +20,7 @@ func format(flowCounts []FlowCount) string {
 	return buf.String()
 }
 
-func assert(t *testing.T, expect, given []FlowCount) {
+func assert(t *testing.T, expect, given []FlowCount[string]) {
 	t.Helper()
 	if len(expect) != len(given) {
 		t.Fatalf("expected %d items, got %d:\nexpect: %s\ngot:    %s", len(expect), len(given), format(expect), format(given))
@@ -42,24 +42,24 @@ func TestHeavyKeeper(t *testing.T) {
 	tests := []struct {
 		desc   string
 		k      int
-		given  []FlowCount
-		expect []FlowCount
+		given  []FlowCount[string]
+		expect []FlowCount[string]
 	}{
 		{
 			desc:   "zero",
 			k:      5,
-			expect: []FlowCount{},
+			expect: []FlowCount[string]{},
 		},
 		{
 			desc: "simple, cardinality < k",
 			k:    5,
-			given: []FlowCount{
+			given: []FlowCount[string]{
 				{"c", 1},
 				{"b", 5},
 				{"a", 10},
 				{"d", 25},
 			},
-			expect: []FlowCount{
+			expect: []FlowCount[string]{
 				{"d", 25},
 				{"a", 10},
 				{"b", 5},
@@ -69,7 +69,7 @@ func TestHeavyKeeper(t *testing.T) {
 		{
 			desc: "simple, cardinality > k",
 			k:    5,
-			given: []FlowCount{
+			given: []FlowCount[string]{
 				{"c", 1},
 				{"b", 5},
 				{"a", 10},
@@ -79,7 +79,7 @@ func TestHeavyKeeper(t *testing.T) {
 				{"h", 100},
 				{"i", 2},
 			},
-			expect: []FlowCount{
+			expect: []FlowCount[string]{
 				{"h", 100},
 				{"d", 25},
 				{"g", 20},
@@ -91,7 +91,7 @@ func TestHeavyKeeper(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.desc, func(t *testing.T) {
-			hk := New(test.k, 0.9)
+			hk := New(test.k, 0.9, StringKey)
 			for _, fc := range test.given {
 				hk.Sample(fc.Flow, fc.Count)
 			}
@@ -101,7 +101,7 @@ func TestHeavyKeeper(t *testing.T) {
 }
 
 func TestSample_returnValue(t *testing.T) {
-	hk := New(2, 0.9)
+	hk := New(2, 0.9, StringKey)
 
 	assert := func(key string, expect bool) {
 		t.Helper()
@@ -123,7 +123,7 @@ func TestSample_returnValue(t *testing.T) {
 }
 
 func TestDecayAll(t *testing.T) {
-	hk := New(5, 0.9)
+	hk := New(5, 0.9, StringKey)
 	hk.Sample("a", 3)
 	hk.Sample("b", 6)
 	hk.Sample("c", 13)
@@ -132,20 +132,20 @@ func TestDecayAll(t *testing.T) {
 	hk.Sample("f", 100)
 
 	hk.DecayAll(0.3)
-	assert(t, []FlowCount{{"f", 70}, {"e", 35}, {"d", 17}, {"c", 9}, {"b", 4}}, hk.Top())
+	assert(t, []FlowCount[string]{{"f", 70}, {"e", 35}, {"d", 17}, {"c", 9}, {"b", 4}}, hk.Top())
 
 	hk.DecayAll(0.9)
 	hk.DecayAll(0.9)
-	assert(t, []FlowCount{}, hk.Top())
+	assert(t, []FlowCount[string]{}, hk.Top())
 }
 
 func TestReset(t *testing.T) {
-	hk := New(5, 0.9)
+	hk := New(5, 0.9, StringKey)
 	hk.Sample("a", 1)
 	hk.Sample("b", 2)
 	hk.Sample("c", 3)
 	hk.Reset()
-	assert(t, []FlowCount{}, hk.Top())
+	assert(t, []FlowCount[string]{}, hk.Top())
 }
 
 func BenchmarkSample(b *testing.B) {
@@ -162,7 +162,7 @@ func BenchmarkSample(b *testing.B) {
 				}
 			}
 			flows := make([]string, b.N)
-			hk := New(k, 0.9)
+			hk := New(k, 0.9, StringKey)
 			b.ResetTimer()
 			for _, flow := range flows[:b.N] {
 				hk.Sample(flow, 1)
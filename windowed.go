@@ -0,0 +1,118 @@
+package topk
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Windowed tracks the top-K flows over a sliding time window, so that Top
+// reflects recent activity rather than all-time counts. It is implemented
+// as a ring of HeavyKeeper sub-sketches, each covering window/buckets of
+// time: Sample always writes to the active sub-sketch, and on each call the
+// ring is rotated forward (zeroing out sub-sketches that have aged out of
+// the window) before Top merges whatever sub-sketches remain active.
+//
+// Windowed is safe for concurrent use.
+type Windowed[T comparable] struct {
+	mu sync.Mutex
+
+	k              int
+	bucketDuration time.Duration
+	sketches       []*HeavyKeeper[T]
+	current        int
+	lastRotate     time.Time
+
+	now func() time.Time
+}
+
+// NewWindowed returns a Windowed that tracks the k largest flows seen within
+// the last window, using buckets sub-sketches internally (more buckets give
+// a smoother decay at the cost of more memory and CPU per Sample).
+func NewWindowed[T comparable](k int, decay float64, window time.Duration, buckets int, serialize SerializeFunc[T]) *Windowed[T] {
+	if buckets < 1 {
+		panic("buckets must be >= 1")
+	}
+	if window <= 0 {
+		panic("window must be > 0")
+	}
+
+	bucketDuration := window / time.Duration(buckets)
+	if bucketDuration <= 0 {
+		panic("window/buckets must be > 0")
+	}
+
+	sketches := make([]*HeavyKeeper[T], buckets)
+	for i := range sketches {
+		sketches[i] = New(k, decay, serialize)
+	}
+
+	return &Windowed[T]{
+		k:              k,
+		bucketDuration: bucketDuration,
+		sketches:       sketches,
+		lastRotate:     time.Now(),
+		now:            time.Now,
+	}
+}
+
+// rotate advances the ring by however many bucket durations have elapsed
+// since the last rotation, resetting the sub-sketches that age out. The
+// caller must hold w.mu.
+func (w *Windowed[T]) rotate() {
+	elapsed := w.now().Sub(w.lastRotate)
+	steps := int(elapsed / w.bucketDuration)
+	if steps <= 0 {
+		return
+	}
+
+	if steps >= len(w.sketches) {
+		for _, hk := range w.sketches {
+			hk.Reset()
+		}
+		w.current = 0
+		w.lastRotate = w.now()
+		return
+	}
+
+	for i := 0; i < steps; i++ {
+		w.current = (w.current + 1) % len(w.sketches)
+		w.sketches[w.current].Reset()
+	}
+	w.lastRotate = w.lastRotate.Add(time.Duration(steps) * w.bucketDuration)
+}
+
+// Sample increments the given flow's count by the given amount in the
+// currently active bucket. It returns true if the flow is in the top K
+// elements of that bucket.
+func (w *Windowed[T]) Sample(flow T, incr uint32) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotate()
+	return w.sketches[w.current].Sample(flow, incr)
+}
+
+// Top returns the top K flows across all buckets still within the window.
+func (w *Windowed[T]) Top() []FlowCount[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotate()
+
+	counts := make(map[T]uint32)
+	for _, hk := range w.sketches {
+		for _, fc := range hk.Top() {
+			counts[fc.Flow] += fc.Count
+		}
+	}
+
+	merged := make([]FlowCount[T], 0, len(counts))
+	for flow, count := range counts {
+		merged = append(merged, FlowCount[T]{Flow: flow, Count: count})
+	}
+
+	sort.Stable(sort.Reverse(byCount[T](merged)))
+	if len(merged) > w.k {
+		merged = merged[:w.k]
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package topk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowed_rotatesOutOldBuckets(t *testing.T) {
+	w := NewWindowed(5, 0.9, 4*time.Minute, 4, StringKey)
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	w.Sample("a", 10)
+
+	now = now.Add(1 * time.Minute)
+	w.Sample("b", 5)
+
+	top := w.Top()
+	if len(top) != 2 {
+		t.Fatalf("expected both flows within the window, got %v", top)
+	}
+
+	// Advance past the full window: every bucket, including "a"'s and "b"'s,
+	// should have aged out.
+	now = now.Add(5 * time.Minute)
+	top = w.Top()
+	if len(top) != 0 {
+		t.Fatalf("expected no flows after the window elapsed, got %v", top)
+	}
+}
+
+func TestWindowed_keepsRecentActivity(t *testing.T) {
+	w := NewWindowed(5, 0.9, 4*time.Minute, 4, StringKey)
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	w.Sample("old", 100)
+
+	// Rotate forward by exactly one bucket's worth of time per sample, so
+	// "old" ages out of the ring while "new" stays in the active bucket.
+	for i := 0; i < 4; i++ {
+		now = now.Add(1 * time.Minute)
+		w.Sample("new", 1)
+	}
+
+	top := w.Top()
+	counts := map[string]uint32{}
+	for _, fc := range top {
+		counts[fc.Flow] = fc.Count
+	}
+
+	if _, ok := counts["old"]; ok {
+		t.Fatalf("expected \"old\" to have aged out of the window, got %v", top)
+	}
+	if counts["new"] == 0 {
+		t.Fatalf("expected \"new\" to still be tracked, got %v", top)
+	}
+}
+
+func TestWindowed_combinesFlowAcrossBuckets(t *testing.T) {
+	w := NewWindowed(5, 0.9, 4*time.Minute, 4, StringKey)
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	w.Sample("x", 100)
+
+	now = now.Add(1 * time.Minute)
+	w.Sample("x", 100)
+
+	top := w.Top()
+	if len(top) != 1 {
+		t.Fatalf("expected a single combined entry for \"x\", got %v", top)
+	}
+	if top[0].Flow != "x" || top[0].Count != 200 {
+		t.Fatalf("expected x=200, got %v", top)
+	}
+}
+
+func TestNewWindowed_zeroBucketDuration(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic when window/buckets rounds down to 0")
+		}
+	}()
+	NewWindowed(5, 0.9, 1, 10, StringKey)
+}